@@ -0,0 +1,232 @@
+package gyro
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// asyncCloseTimeout bounds how long Close waits for the async flusher
+// to drain its buffer before giving up.
+const asyncCloseTimeout = 5 * time.Second
+
+// EnableAsync decouples Write from disk I/O: writes are copied into a
+// buffered channel and appended to the current file by a single
+// background goroutine. bufBytes sets the channel's capacity, i.e. how
+// many pending writes may queue before Write starts applying
+// back-pressure. Calling EnableAsync more than once is a no-op, as is
+// calling it after SetMaxSize or SetMaxLines: async mode has its own
+// size-based rollover, SetAsyncMaxSize, and doesn't support being
+// combined with sharded (MaxSize/MaxLines) filenames.
+func (l *Logger) EnableAsync(bufBytes int) {
+	if l.async.Load() != nil || l.shardingEnabled() {
+		return
+	}
+
+	a := &asyncWriter{
+		logger: l,
+		buf:    make(chan []byte, bufBytes),
+		done:   make(chan struct{}),
+	}
+	if !l.async.CompareAndSwap(nil, a) {
+		return
+	}
+
+	a.wg.Add(1)
+	go a.run()
+}
+
+// SetAsyncMaxSize sets the byte threshold at which the async flusher
+// rolls the current file over to a sequence-suffixed shard rather than
+// waiting for the next layout boundary. A zero value (the default)
+// disables size-based rollover.
+func (l *Logger) SetAsyncMaxSize(bytes int64) {
+	if a := l.async.Load(); a != nil {
+		a.maxSize.Store(bytes)
+	}
+}
+
+// asyncWriter owns the file handle used by async mode and the
+// goroutine that drains buffered writes onto it.
+type asyncWriter struct {
+	logger *Logger
+
+	buf chan []byte
+
+	maxSize atomic.Int64
+
+	f        *os.File
+	fileName string
+	written  int64
+	seq      int
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// write copies data and hands it to the drain goroutine, returning as
+// soon as it has been queued rather than once it has reached disk.
+func (a *asyncWriter) write(data []byte) (int, error) {
+	cp := make([]byte, len(data))
+	copy(cp, data)
+
+	select {
+	case a.buf <- cp:
+		return len(data), nil
+	case <-a.done:
+		return 0, fmt.Errorf("gyro: async writer is closed")
+	}
+}
+
+// close signals the drain goroutine to flush whatever is left in buf
+// and shut down, waiting up to timeout for it to finish.
+func (a *asyncWriter) close(timeout time.Duration) error {
+	a.closeOnce.Do(func() { close(a.done) })
+
+	finished := make(chan struct{})
+	go func() {
+		a.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("gyro: async writer flush timed out after %s", timeout)
+	}
+}
+
+// run drains buffered writes onto disk until close is called, rotating
+// the open file promptly at layout boundaries even if writes are
+// sparse.
+func (a *asyncWriter) run() {
+	defer a.wg.Done()
+	defer a.closeFile()
+
+	ticker := newTimeTicker(a.logger.layout, a.logger.timeFn)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case data := <-a.buf:
+			a.append(data)
+		case <-ticker.C:
+			a.rotate()
+			ticker.reset()
+		case <-a.done:
+			a.drain()
+			return
+		}
+	}
+}
+
+// drain flushes whatever is left in buf without blocking for more.
+func (a *asyncWriter) drain() {
+	for {
+		select {
+		case data := <-a.buf:
+			a.append(data)
+		default:
+			return
+		}
+	}
+}
+
+// append writes data to the current file, opening or rotating it first
+// if the logger's filename has moved on or the size threshold has been
+// crossed.
+func (a *asyncWriter) append(data []byte) {
+	name := a.logger.FileName()
+	if name != a.fileName {
+		prevName, wasOpen := a.fileName, a.f != nil
+		a.closeFile()
+		a.fileName = name
+		a.seq = 0
+		a.written = 0
+
+		if wasOpen {
+			a.logger.emitRotation(path.Join(a.logger.path, prevName), path.Join(a.logger.path, name))
+		}
+	}
+
+	if a.f == nil {
+		if err := a.openFile(); err != nil {
+			return
+		}
+	}
+
+	if max := a.maxSize.Load(); max > 0 && a.written+int64(len(data)) > max {
+		a.rolloverSize()
+	}
+
+	n, _ := a.f.Write(data)
+	a.written += int64(n)
+}
+
+// rotate proactively closes the file open for the previous layout
+// bucket and opens the one for the current bucket. It is called from
+// the boundary ticker so rotation happens promptly even without a
+// pending write.
+func (a *asyncWriter) rotate() {
+	name := a.logger.FileName()
+	if name == a.fileName && a.f != nil {
+		return
+	}
+
+	prevName, wasOpen := a.fileName, a.f != nil
+	a.closeFile()
+	a.fileName = name
+	a.seq = 0
+	a.written = 0
+	a.openFile()
+
+	if wasOpen {
+		a.logger.emitRotation(path.Join(a.logger.path, prevName), path.Join(a.logger.path, name))
+	}
+}
+
+// rolloverSize renames the current file with a sequence suffix before
+// opening a fresh one for the same layout bucket.
+func (a *asyncWriter) rolloverSize() {
+	a.closeFile()
+	a.seq++
+
+	oldPath := path.Join(a.logger.path, a.fileName)
+	newPath := path.Join(a.logger.path, shardedName(a.fileName, a.seq))
+	os.Rename(oldPath, newPath)
+
+	a.written = 0
+	a.openFile()
+}
+
+// shardedName inserts a sequence number before the extension of name,
+// e.g. shardedName("pre_1970-01-01T00.log", 1) => "pre_1970-01-01T00.1.log".
+func shardedName(name string, n int) string {
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	return fmt.Sprintf("%s.%d%s", base, n, ext)
+}
+
+func (a *asyncWriter) openFile() error {
+	full := path.Join(a.logger.path, a.fileName)
+	f, err := os.OpenFile(full, flags, fileMode)
+	if err != nil {
+		return err
+	}
+	a.f = f
+	a.logger.updateCurrentLink(full)
+	return nil
+}
+
+func (a *asyncWriter) closeFile() {
+	if a.f != nil {
+		a.f.Close()
+		a.f = nil
+	}
+}
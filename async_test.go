@@ -0,0 +1,77 @@
+package gyro
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsyncWriteFlushesOnClose(t *testing.T) {
+	dir := t.TempDir()
+
+	logger, err := New(dir)
+	require.NoError(t, err)
+	logger.SetTimeFunction(func() time.Time { return time.Unix(0, 0).UTC() })
+	logger.EnableAsync(8)
+
+	require.NoError(t, logger.WriteString("test line 1\n"))
+	require.NoError(t, logger.WriteString("test line 2\n"))
+	require.NoError(t, logger.Close())
+
+	contents, err := os.ReadFile(filepath.Join(dir, logger.FileName()))
+	require.NoError(t, err)
+	assert.Equal(t, "test line 1\ntest line 2\n", string(contents))
+}
+
+func TestAsyncMaxSizeRollsOverToSequencedFile(t *testing.T) {
+	dir := t.TempDir()
+
+	logger, err := New(dir)
+	require.NoError(t, err)
+	logger.SetTimeFunction(func() time.Time { return time.Unix(0, 0).UTC() })
+	logger.EnableAsync(8)
+	logger.SetAsyncMaxSize(5)
+
+	require.NoError(t, logger.WriteString("ab\n"))     // 3 bytes, under the threshold
+	require.NoError(t, logger.WriteString("cdefgh\n")) // would push written past 5, rolls over first
+	require.NoError(t, logger.Close())
+
+	rolled, err := os.ReadFile(filepath.Join(dir, "1970-01-01T00.1.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "ab\n", string(rolled))
+
+	current, err := os.ReadFile(filepath.Join(dir, logger.FileName()))
+	require.NoError(t, err)
+	assert.Equal(t, "cdefgh\n", string(current))
+}
+
+func TestAsyncAndShardingAreMutuallyExclusive(t *testing.T) {
+	dir := t.TempDir()
+
+	async, err := New(dir)
+	require.NoError(t, err)
+	async.SetTimeFunction(func() time.Time { return time.Unix(0, 0).UTC() })
+	async.EnableAsync(8)
+
+	// SetMaxSize/SetMaxLines must not take effect once async mode is on:
+	// the async flusher never consults them, so accepting them would
+	// switch FileName to sharded names that never advance. They are
+	// ignored instead, leaving sharding disabled.
+	async.SetMaxSize(10)
+	async.SetMaxLines(1)
+	assert.False(t, async.shardingEnabled())
+	require.NoError(t, async.Close())
+
+	sharded, err := New(dir)
+	require.NoError(t, err)
+	sharded.SetTimeFunction(func() time.Time { return time.Unix(0, 0).UTC() })
+	sharded.SetMaxSize(10)
+
+	// Likewise, EnableAsync must not take effect once sharding is on.
+	sharded.EnableAsync(8)
+	assert.Nil(t, sharded.async.Load())
+}
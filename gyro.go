@@ -2,12 +2,14 @@ package gyro
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -33,11 +35,121 @@ type Logger struct {
 	// what time to use when formatting filenames
 	timeFn func() time.Time
 
-	// holds the pre-formatted string for filenames
-	format string
+	// fmtSpec holds the filename format built by buildFormatString,
+	// swapped in atomically as a single, consistent snapshot so
+	// Write/FileName/etc. can read it without racing whichever Set*
+	// call (SetPrefix, SetMaxSize, ...) is rebuilding it.
+	fmtSpec atomic.Pointer[filenameFormat]
 
-	// protects against concurrent writes
+	// protects the retention, current-link and rotation-notification
+	// settings and janitorCancel; the filename setters (SetPrefix,
+	// SetSuffix, ...) do not take it. Not held across Write
 	mu *sync.Mutex
+
+	// current is the *os.File presently open for writing, paired with the
+	// filename it was opened for. Swapped in by swapFile when FileName()
+	// moves on to a new rotation bucket.
+	current atomic.Pointer[openFile]
+
+	// swapMu serializes the (rare) transition to a newly rotated file.
+	// The actual write to the open file is serialized by the openFile's
+	// own mutex instead of one shared across the whole Logger, see
+	// openFile.write.
+	swapMu sync.Mutex
+
+	// namePool recycles the byte buffers Write formats filenames into
+	namePool sync.Pool
+
+	// retention policy, enforced by the janitor started with Start
+	maxAge          time.Duration
+	maxBackups      int
+	janitorInterval time.Duration
+	janitorCancel   context.CancelFunc
+	wg              sync.WaitGroup
+
+	// set by EnableAsync; when non-nil, Write hands off to it instead of
+	// writing to disk synchronously
+	async atomic.Pointer[asyncWriter]
+
+	// currentLink, if set with SetCurrentLink, is kept pointing at the
+	// most recently opened log file
+	currentLink string
+
+	// notified whenever FileName() moves on to a new value
+	rotationSink chan<- RotationEvent
+	onRotate     []OnRotate
+
+	// size/line-count rotation thresholds, set by SetMaxSize/SetMaxLines.
+	// Atomic because shardingEnabled reads them from the hot Write/
+	// FileName/buildFormatString paths, which never take l.mu.
+	maxSize  atomic.Int64
+	maxLines atomic.Int64
+
+	// shardMu is held by writeSharded across each sharded write's
+	// threshold check, write and counter update, and by FileName while it
+	// resumes a new bucket; the counters are atomic so they can also be
+	// loaded without it (FileName reads shardIndex after releasing it)
+	shardMu    sync.Mutex
+	bucket     atomic.Pointer[string]
+	shardIndex atomic.Int64
+	shardBytes atomic.Int64
+	shardLines atomic.Int64
+}
+
+// filenameFormat bundles the fields buildFormatString computes so they
+// can be swapped into l.fmtSpec together, as one immutable snapshot,
+// instead of as separate fields a reader could observe half-updated.
+type filenameFormat struct {
+	// format is the fmt.Sprintf template for filenames, e.g.
+	// "pre_%s_suf.txt", or "pre_%s.%03d_suf.txt" when sharding is
+	// enabled.
+	format string
+
+	// pre, mid and post are the static parts of format around its "%s"
+	// (and, when sharding is enabled, "%d") placeholders, cached so
+	// Write can build a filename with append/AppendFormat instead of
+	// fmt.Sprintf. mid is only meaningful when sharding is enabled.
+	pre  []byte
+	mid  []byte
+	post []byte
+}
+
+// openFile pairs an open *os.File with the filename (relative to
+// l.path) it was opened for. mu serializes writes to f and guards
+// closed so a writer that loaded this openFile just before it was
+// rotated out never writes to (or double-closes) a file out from under
+// swapFile: writeToName re-reads l.current and retries when it finds
+// closed set instead of writing.
+type openFile struct {
+	f    *os.File
+	name []byte
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// write writes data to f, unless a concurrent swapFile has already
+// closed it, in which case it reports retry so the caller re-reads
+// l.current and tries again against whatever is open now.
+func (o *openFile) write(data []byte) (n int, retry bool, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.closed {
+		return 0, true, nil
+	}
+
+	n, err = o.f.Write(data)
+	return n, false, err
+}
+
+// close marks o closed, under the same lock write takes, so no write
+// can land on f after Close() has returned.
+func (o *openFile) close() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.closed = true
+	o.f.Close()
 }
 
 // New returns a new rotating logger with the default values.
@@ -48,6 +160,7 @@ func New(path string) (*Logger, error) {
 		layout:    defaultLayout,
 		extension: defaultExtension,
 		timeFn:    func() time.Time { return time.Now().UTC() },
+		namePool:  sync.Pool{New: func() interface{} { return make([]byte, 0, 128) }},
 	}
 
 	// Make sure we have write permissions in l.path
@@ -99,26 +212,135 @@ func (l *Logger) SetTimeFunction(f func() time.Time) {
 
 // FileName returns the current filename
 func (l *Logger) FileName() string {
-	return fmt.Sprintf(l.format, l.timeFn().Format(l.layout))
+	spec := l.fmtSpec.Load()
+	if l.shardingEnabled() {
+		bucket := l.timeFn().Format(l.layout)
+		l.resumeShardForBucket(bucket)
+		return fmt.Sprintf(spec.format, bucket, l.shardIndex.Load())
+	}
+	return fmt.Sprintf(spec.format, l.timeFn().Format(l.layout))
 }
 
-// Write writes the byte data to the log file
+// Write writes the byte data to the log file. If async mode has been
+// enabled with EnableAsync, the write is handed off to the background
+// flusher instead of touching disk on this goroutine.
+//
+// The synchronous path keeps a file handle open across calls instead of
+// reopening it every time: it only takes l.swapMu, a narrow lock, when
+// the desired filename has actually changed, and otherwise serializes
+// only against other writers of the same open file, via that
+// openFile's own mutex (see writeToName).
 func (l *Logger) Write(data []byte) (int, error) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	if a := l.async.Load(); a != nil {
+		return a.write(data)
+	}
+
+	if l.shardingEnabled() {
+		return l.writeSharded(data)
+	}
 
-	f, err := os.OpenFile(path.Join(l.path, l.FileName()), flags, fileMode)
+	spec := l.fmtSpec.Load()
+	name := l.namePool.Get().([]byte)[:0]
+	name = append(name, spec.pre...)
+	name = l.timeFn().AppendFormat(name, l.layout)
+	name = append(name, spec.post...)
+
+	return l.writeToName(name, data)
+}
+
+// writeToName ensures the file for name is the one open in l.current
+// and writes data to it. Callers must not retain name past this call.
+//
+// A writer can load l.current just before another goroutine's swapFile
+// closes that same openFile (both having crossed a rotation boundary at
+// nearly the same instant); write's retry return catches that and sends
+// this call back through swapFile for whatever is current now, instead
+// of writing to an already-closed file.
+func (l *Logger) writeToName(name, data []byte) (int, error) {
+	defer l.putName(name)
+
+	for {
+		cur := l.current.Load()
+		if cur == nil || !bytes.Equal(cur.name, name) {
+			var err error
+			cur, err = l.swapFile(name)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		n, retry, err := cur.write(data)
+		if retry {
+			continue
+		}
+
+		if n != len(data) {
+			return n, fmt.Errorf("Didnt write all data. Wrote %d out of %d bytes", n, len(data))
+		}
+
+		return n, err
+	}
+}
+
+// swapFile opens the file for the given (pool-borrowed) name and
+// installs it as l.current, closing whatever was open before. Callers
+// must not retain name past this call; swapFile copies it.
+//
+// emitRotation is dispatched from a goroutine after swapMu is released
+// rather than called inline: OnRotate handlers like CompressOnRotate
+// can take real wall-clock time, and running them under swapMu would
+// stall not just the Write that happened to cross the boundary but
+// every other writer waiting to rotate behind it.
+func (l *Logger) swapFile(name []byte) (*openFile, error) {
+	l.swapMu.Lock()
+
+	// l.current may have already been swapped to what we want while we
+	// were waiting for swapMu.
+	if cur := l.current.Load(); cur != nil && bytes.Equal(cur.name, name) {
+		l.swapMu.Unlock()
+		return cur, nil
+	}
+
+	full := path.Join(l.path, string(name))
+	f, err := os.OpenFile(full, flags, fileMode)
 	if err != nil {
-		return 0, err
+		l.swapMu.Unlock()
+		return nil, err
 	}
-	defer f.Close()
 
-	n, err := f.Write(data)
-	if n != len(data) {
-		return n, fmt.Errorf("Didnt write all data. Wrote %d out of %d bytes", n, len(data))
+	next := &openFile{f: f, name: append([]byte(nil), name...)}
+	prev := l.current.Swap(next)
+
+	var prevFull string
+	if prev != nil {
+		prevFull = path.Join(l.path, string(prev.name))
+		prev.close()
+	}
+
+	l.updateCurrentLink(full)
+	l.swapMu.Unlock()
+
+	if prevFull != "" {
+		l.wg.Add(1)
+		go func() {
+			defer l.wg.Done()
+			l.emitRotation(prevFull, full)
+		}()
 	}
 
-	return n, err
+	return next, nil
+}
+
+// maxPooledNameCap bounds the capacity of buffers returned to namePool.
+// Without this, one abnormally long filename would grow the pooled
+// buffer and pin that memory forever (golang/go#23199).
+const maxPooledNameCap = 64 * 1024
+
+func (l *Logger) putName(name []byte) {
+	if cap(name) > maxPooledNameCap {
+		return
+	}
+	l.namePool.Put(name)
 }
 
 // WriteString writes the data string to the log file
@@ -137,7 +359,7 @@ func (l *Logger) String() string {
 	buffer.WriteString(fmt.Sprintf("  separator: %q\n", l.separator))
 	buffer.WriteString(fmt.Sprintf("  extension: %q\n", l.extension))
 	buffer.WriteString(fmt.Sprintf("  layout: %s\n", l.layout))
-	buffer.WriteString(fmt.Sprintf("  format: %s\n", l.format))
+	buffer.WriteString(fmt.Sprintf("  format: %s\n", l.fmtSpec.Load().format))
 	buffer.WriteString(fmt.Sprintf("  current filename: %s\n", l.FileName()))
 
 	return strings.TrimSpace(buffer.String())
@@ -155,21 +377,45 @@ func (l *Logger) canWrite() error {
 }
 
 // buildFormatString precalculates the format string for the filenames
-// so we dont have to do the string interpolation on each call
+// so we dont have to do the string interpolation on each call. When
+// MaxSize or MaxLines is configured, the timestamp placeholder grows a
+// second, "%s.%03d", slot for the shard index; otherwise it stays a
+// bare "%s", which keeps filenames unchanged for loggers that never
+// touch shard-based rotation.
 func (l *Logger) buildFormatString() {
 	p, s := len(l.prefix) > 0, len(l.suffix) > 0
 
+	ts := "%s"
+	if l.shardingEnabled() {
+		ts = "%s.%03d"
+	}
+
+	var format string
 	if p && s {
-		l.format = strings.TrimSpace(fmt.Sprintf("%s%s%s%s%s.%s", l.prefix, l.separator, "%s", l.separator, l.suffix, l.extension))
+		format = strings.TrimSpace(fmt.Sprintf("%s%s%s%s%s.%s", l.prefix, l.separator, ts, l.separator, l.suffix, l.extension))
 	} else if p && !s {
-		l.format = strings.TrimSpace(fmt.Sprintf("%s%s%s.%s", l.prefix, l.separator, "%s", l.extension))
+		format = strings.TrimSpace(fmt.Sprintf("%s%s%s.%s", l.prefix, l.separator, ts, l.extension))
 	} else if !p && s {
-		l.format = strings.TrimSpace(fmt.Sprintf("%s%s%s.%s", "%s", l.separator, l.suffix, l.extension))
+		format = strings.TrimSpace(fmt.Sprintf("%s%s%s.%s", ts, l.separator, l.suffix, l.extension))
 	} else {
-		l.format = strings.TrimSpace(fmt.Sprintf("%s.%s", "%s", l.extension))
+		format = strings.TrimSpace(fmt.Sprintf("%s.%s", ts, l.extension))
 	}
 
 	if len(l.extension) == 0 {
-		l.format = l.format[:len(l.format)-1]
+		format = format[:len(format)-1]
 	}
+
+	spec := &filenameFormat{format: format}
+	if l.shardingEnabled() {
+		parts := strings.SplitN(format, "%s.%03d", 2)
+		spec.pre = []byte(parts[0])
+		spec.mid = []byte(".")
+		spec.post = []byte(parts[1])
+	} else {
+		parts := strings.SplitN(format, "%s", 2)
+		spec.pre = []byte(parts[0])
+		spec.post = []byte(parts[1])
+	}
+
+	l.fmtSpec.Store(spec)
 }
@@ -0,0 +1,103 @@
+package gyro
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestConcurrentWritesAcrossRotationBoundary drives many goroutines
+// through Write while the clock alternates buckets on every call, so
+// writers routinely load l.current just as another goroutine's
+// swapFile is rotating and closing it. Before the openFile-level
+// closed check, this reliably produced "file already closed" errors,
+// reported upstream as a partial write instead of the real cause.
+func TestConcurrentWritesAcrossRotationBoundary(t *testing.T) {
+	dir := t.TempDir()
+
+	logger, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tick atomic.Int64
+	logger.SetTimeFunction(func() time.Time {
+		if tick.Add(1)%2 == 0 {
+			return time.Unix(0, 0).UTC()
+		}
+		return time.Unix(3600, 0).UTC()
+	})
+
+	const goroutines = 50
+	const perGoroutine = 20
+	line := []byte("x\n")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, goroutines*perGoroutine)
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perGoroutine; j++ {
+				if _, err := logger.Write(line); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("unexpected write error: %v", err)
+	}
+}
+
+// TestConcurrentSetMaxSizeAndWrite calls SetMaxSize/SetMaxLines from
+// one goroutine while others hit the Write/FileName hot path, which
+// reads them via shardingEnabled. maxSize/maxLines are atomic.Int64
+// specifically so this can't race under `go test -race`.
+func TestConcurrentSetMaxSizeAndWrite(t *testing.T) {
+	dir := t.TempDir()
+
+	logger, err := New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.SetTimeFunction(func() time.Time { return time.Unix(0, 0).UTC() })
+
+	stop := make(chan struct{})
+
+	var setterWG sync.WaitGroup
+	setterWG.Add(1)
+	go func() {
+		defer setterWG.Done()
+		for i := int64(1); ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+				logger.SetMaxSize(i % 100)
+				logger.SetMaxLines(i % 10)
+			}
+		}
+	}()
+
+	const goroutines = 20
+	var writersWG sync.WaitGroup
+	writersWG.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func() {
+			defer writersWG.Done()
+			for j := 0; j < 50; j++ {
+				logger.Write([]byte("x\n"))
+				logger.FileName()
+			}
+		}()
+	}
+
+	writersWG.Wait()
+	close(stop)
+	setterWG.Wait()
+}
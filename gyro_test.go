@@ -60,7 +60,7 @@ func TestFilenames(t *testing.T) {
 	assert.Equal(t, "pre", logger.prefix)
 	assert.Equal(t, "suf", logger.suffix)
 	assert.Equal(t, "2006010215", logger.layout)
-	assert.Equal(t, "pre_%s_suf.txt", logger.format)
+	assert.Equal(t, "pre_%s_suf.txt", logger.fmtSpec.Load().format)
 	assert.True(t, time.Unix(0, 0).UTC().Equal(logger.timeFn()))
 }
 
@@ -0,0 +1,46 @@
+package gyro
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// SetCurrentLink configures a symlink (falling back to a hardlink where
+// symlinks aren't available) at path that always points at the file
+// gyro most recently opened for writing. It is refreshed after every
+// rotation, i.e. whenever FileName() produces a value different from
+// the one currently open, which makes `tail -F path` follow the active
+// log file across rotations.
+func (l *Logger) SetCurrentLink(path string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.currentLink = path
+}
+
+// updateCurrentLink atomically repoints the configured current-link at
+// target. It links to a temp name in the link's directory and renames
+// it into place so a tool tailing the link never observes it missing.
+func (l *Logger) updateCurrentLink(target string) {
+	l.mu.Lock()
+	link := l.currentLink
+	l.mu.Unlock()
+
+	if link == "" {
+		return
+	}
+
+	tmp := filepath.Join(filepath.Dir(link), "."+filepath.Base(link)+".tmp")
+	os.Remove(tmp)
+
+	if err := os.Symlink(target, tmp); err != nil {
+		// Symlink creation can fail where the platform or filesystem
+		// doesn't support it, e.g. Windows without
+		// SeCreateSymbolicLinkPrivilege returns EPERM. Fall back to a
+		// hardlink, which needs no special privilege.
+		if err := os.Link(target, tmp); err != nil {
+			return
+		}
+	}
+
+	os.Rename(tmp, link)
+}
@@ -0,0 +1,40 @@
+package gyro
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetCurrentLinkFollowsRotation(t *testing.T) {
+	dir := t.TempDir()
+
+	logger, err := New(dir)
+	require.NoError(t, err)
+
+	linkPath := filepath.Join(dir, "current.log")
+	logger.SetCurrentLink(linkPath)
+
+	logger.SetTimeFunction(func() time.Time { return time.Unix(0, 0).UTC() })
+	require.NoError(t, logger.WriteString("first\n"))
+
+	firstTarget, err := os.Readlink(linkPath)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, logger.FileName()), firstTarget)
+
+	logger.SetTimeFunction(func() time.Time { return time.Unix(3600, 0).UTC() })
+	require.NoError(t, logger.WriteString("second\n"))
+
+	secondTarget, err := os.Readlink(linkPath)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, logger.FileName()), secondTarget)
+	assert.NotEqual(t, firstTarget, secondTarget)
+
+	contents, err := os.ReadFile(linkPath)
+	require.NoError(t, err)
+	assert.Equal(t, "second\n", string(contents))
+}
@@ -0,0 +1,192 @@
+package gyro
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defaultJanitorInterval is how often the retention janitor scans l.path
+// when no custom interval has been configured.
+const defaultJanitorInterval = time.Minute
+
+// SetMaxAge sets the maximum age a rotated log file is allowed to reach
+// before the janitor removes it. A zero value (the default) disables
+// age-based cleanup.
+func (l *Logger) SetMaxAge(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.maxAge = d
+}
+
+// SetMaxBackups sets the maximum number of log files matching the
+// logger's filename format to keep, counting the file currently being
+// written to. Once exceeded, the oldest files as determined by their
+// parsed timestamp are removed. A zero value (the default) disables
+// count-based cleanup.
+func (l *Logger) SetMaxBackups(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.maxBackups = n
+}
+
+// Start launches the background janitor that enforces the configured
+// MaxAge and MaxBackups retention policy. It is safe to call even if no
+// retention policy has been configured, in which case the janitor has
+// nothing to do. Start returns immediately; the janitor keeps running
+// until ctx is cancelled or Close is called. Calling Start again while
+// the janitor is already running is a no-op.
+func (l *Logger) Start(ctx context.Context) {
+	l.mu.Lock()
+	if l.janitorCancel != nil {
+		l.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	l.janitorCancel = cancel
+	l.mu.Unlock()
+
+	l.wg.Add(1)
+	go l.runJanitor(ctx)
+}
+
+// Close stops the background janitor, if one is running, and waits for
+// its current scan to finish, along with any OnRotate handlers still
+// running for a rotation that hasn't finished yet (see swapFile). If
+// async mode has been enabled, Close also flushes and shuts down the
+// background flusher.
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	cancel := l.janitorCancel
+	l.janitorCancel = nil
+	l.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	l.wg.Wait()
+
+	if async := l.async.Load(); async != nil {
+		return async.close(asyncCloseTimeout)
+	}
+	return nil
+}
+
+// runJanitor periodically enforces the retention policy until ctx is
+// cancelled.
+func (l *Logger) runJanitor(ctx context.Context) {
+	defer l.wg.Done()
+
+	interval := l.janitorInterval
+	if interval <= 0 {
+		interval = defaultJanitorInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	l.cleanup()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.cleanup()
+		}
+	}
+}
+
+// managedFile is a rotated log file found in l.path alongside the
+// timestamp parsed out of its name.
+type managedFile struct {
+	path      string
+	timestamp time.Time
+}
+
+// cleanup removes managed files that violate the configured MaxAge or
+// MaxBackups policy.
+func (l *Logger) cleanup() {
+	l.mu.Lock()
+	maxAge := l.maxAge
+	maxBackups := l.maxBackups
+	l.mu.Unlock()
+
+	if maxAge <= 0 && maxBackups <= 0 {
+		return
+	}
+
+	files := l.managedFiles()
+	if len(files) == 0 {
+		return
+	}
+
+	// Sort by parsed timestamp, not mtime, so retention is deterministic
+	// even if files were copied around.
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].timestamp.Before(files[j].timestamp)
+	})
+
+	now := l.timeFn()
+	survivors := files[:0]
+	for _, f := range files {
+		if maxAge > 0 && now.Sub(f.timestamp) > maxAge {
+			os.Remove(f.path)
+			continue
+		}
+		survivors = append(survivors, f)
+	}
+
+	if maxBackups > 0 && len(survivors) > maxBackups {
+		for _, f := range survivors[:len(survivors)-maxBackups] {
+			os.Remove(f.path)
+		}
+	}
+}
+
+// managedFiles returns the files in l.path that match the current
+// format string, validated by parsing their timestamp segment back
+// through l.layout so unrelated files are never mistaken for ours.
+func (l *Logger) managedFiles() []managedFile {
+	spec := l.fmtSpec.Load()
+	pre, suf := string(spec.pre), string(spec.post)
+
+	l.mu.Lock()
+	layout := l.layout
+	l.mu.Unlock()
+	sharded := l.shardingEnabled()
+
+	glob := pre + "*" + suf
+	matches, err := filepath.Glob(filepath.Join(l.path, glob))
+	if err != nil {
+		return nil
+	}
+
+	var files []managedFile
+	for _, m := range matches {
+		name := filepath.Base(m)
+		if !strings.HasPrefix(name, pre) || !strings.HasSuffix(name, suf) {
+			continue
+		}
+
+		ts := name[len(pre) : len(name)-len(suf)]
+		if sharded {
+			// ts is "<timestamp>.<shard>"; the shard segment isn't part
+			// of layout, so drop it before parsing.
+			if i := strings.LastIndex(ts, "."); i >= 0 {
+				ts = ts[:i]
+			}
+		}
+
+		t, err := time.Parse(layout, ts)
+		if err != nil {
+			continue
+		}
+
+		files = append(files, managedFile{path: m, timestamp: t})
+	}
+
+	return files
+}
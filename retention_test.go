@@ -0,0 +1,54 @@
+package gyro
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func touchManaged(t *testing.T, dir, name string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("x"), fileMode))
+}
+
+func TestCleanupMaxAge(t *testing.T) {
+	dir := t.TempDir()
+
+	logger, err := New(dir)
+	require.NoError(t, err)
+	logger.SetTimeFunction(func() time.Time { return time.Date(1970, 1, 5, 0, 0, 0, 0, time.UTC) })
+	logger.SetMaxAge(48 * time.Hour)
+
+	touchManaged(t, dir, "1970-01-01T00.log") // 4 days old, should be removed
+	touchManaged(t, dir, "1970-01-04T00.log") // 1 day old, should survive
+
+	logger.cleanup()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "1970-01-04T00.log", entries[0].Name())
+}
+
+func TestCleanupMaxBackups(t *testing.T) {
+	dir := t.TempDir()
+
+	logger, err := New(dir)
+	require.NoError(t, err)
+	logger.SetTimeFunction(func() time.Time { return time.Date(1970, 1, 5, 0, 0, 0, 0, time.UTC) })
+	logger.SetMaxBackups(1)
+
+	touchManaged(t, dir, "1970-01-01T00.log")
+	touchManaged(t, dir, "1970-01-02T00.log")
+
+	logger.cleanup()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "1970-01-02T00.log", entries[0].Name())
+}
@@ -0,0 +1,107 @@
+package gyro
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"time"
+)
+
+// RotationEvent describes a single rotation: the file that was just
+// closed and the one now open in its place.
+type RotationEvent struct {
+	PreviousPath string
+	NewPath      string
+	Time         time.Time
+}
+
+// OnRotate is called after a rotation has completed, i.e. after
+// PreviousPath has already been closed. Handlers for a given rotation
+// run synchronously with each other, in registration order. For the
+// synchronous Write path they run on a dedicated goroutine dispatched
+// off the write path, so a slow handler (e.g. CompressOnRotate on a
+// large file) never stalls Write. In async mode (EnableAsync) they run
+// inline on the flusher goroutine, so a slow handler delays draining
+// the buffer until it returns. Close waits for any handlers still
+// running. A returned error cannot undo the rotation and is only useful
+// to the handler itself.
+type OnRotate func(RotationEvent) error
+
+// SetRotationSink configures a channel that receives a RotationEvent
+// for every rotation. The send is non-blocking: if ch isn't ready to
+// receive, the event is dropped rather than stalling Write.
+func (l *Logger) SetRotationSink(ch chan<- RotationEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rotationSink = ch
+}
+
+// AddOnRotate registers fn to be called after every rotation.
+func (l *Logger) AddOnRotate(fn OnRotate) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.onRotate = append(l.onRotate, fn)
+}
+
+// emitRotation notifies the configured rotation sink and OnRotate
+// handlers, if any, that prev has been closed in favor of next.
+func (l *Logger) emitRotation(prev, next string) {
+	l.mu.Lock()
+	sink := l.rotationSink
+	handlers := append([]OnRotate(nil), l.onRotate...)
+	l.mu.Unlock()
+
+	if sink == nil && len(handlers) == 0 {
+		return
+	}
+
+	ev := RotationEvent{PreviousPath: prev, NewPath: next, Time: l.timeFn()}
+
+	if sink != nil {
+		select {
+		case sink <- ev:
+		default:
+		}
+	}
+
+	for _, h := range handlers {
+		h(ev)
+	}
+}
+
+// CompressOnRotate returns an OnRotate handler that gzips
+// ev.PreviousPath to ev.PreviousPath+".gz" at the given compression
+// level and removes the original on success. It streams through a
+// gzip.Writer so compressing a large rotated file doesn't blow up the
+// heap.
+func CompressOnRotate(level int) OnRotate {
+	return func(ev RotationEvent) error {
+		in, err := os.Open(ev.PreviousPath)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := os.OpenFile(ev.PreviousPath+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fileMode)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+
+		gz, err := gzip.NewWriterLevel(out, level)
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.Copy(gz, in); err != nil {
+			gz.Close()
+			return err
+		}
+
+		if err := gz.Close(); err != nil {
+			return err
+		}
+
+		return os.Remove(ev.PreviousPath)
+	}
+}
@@ -0,0 +1,71 @@
+package gyro
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotationSinkReceivesEvent(t *testing.T) {
+	dir := t.TempDir()
+
+	logger, err := New(dir)
+	require.NoError(t, err)
+
+	ch := make(chan RotationEvent, 1)
+	logger.SetRotationSink(ch)
+
+	logger.SetTimeFunction(func() time.Time { return time.Unix(0, 0).UTC() })
+	require.NoError(t, logger.WriteString("first\n"))
+
+	logger.SetTimeFunction(func() time.Time { return time.Unix(3600, 0).UTC() })
+	require.NoError(t, logger.WriteString("second\n"))
+
+	// emitRotation runs on its own goroutine (see swapFile); Close waits
+	// for it, giving the sink send a happens-before edge to land on.
+	require.NoError(t, logger.Close())
+
+	select {
+	case ev := <-ch:
+		assert.Equal(t, filepath.Join(dir, "1970-01-01T00.log"), ev.PreviousPath)
+		assert.Equal(t, filepath.Join(dir, "1970-01-01T01.log"), ev.NewPath)
+	default:
+		t.Fatal("expected a rotation event on the sink")
+	}
+}
+
+func TestCompressOnRotate(t *testing.T) {
+	dir := t.TempDir()
+
+	logger, err := New(dir)
+	require.NoError(t, err)
+	logger.AddOnRotate(CompressOnRotate(gzip.DefaultCompression))
+
+	logger.SetTimeFunction(func() time.Time { return time.Unix(0, 0).UTC() })
+	require.NoError(t, logger.WriteString("first\n"))
+
+	logger.SetTimeFunction(func() time.Time { return time.Unix(3600, 0).UTC() })
+	require.NoError(t, logger.WriteString("second\n"))
+
+	// CompressOnRotate now runs off the write path; Close waits for it.
+	require.NoError(t, logger.Close())
+
+	_, err = os.Stat(filepath.Join(dir, "1970-01-01T00.log"))
+	assert.True(t, os.IsNotExist(err))
+
+	f, err := os.Open(filepath.Join(dir, "1970-01-01T00.log.gz"))
+	require.NoError(t, err)
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	contents, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Equal(t, "first\n", string(contents))
+}
@@ -0,0 +1,201 @@
+package gyro
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SetMaxSize sets the byte threshold at which a log file is rotated to
+// a new shard within the same layout bucket, matching Beego's
+// fileLogWriter behavior. A zero value (the default) disables
+// size-based rotation. Enabling either SetMaxSize or SetMaxLines grows
+// the filename with a shard index (see buildFormatString); disabling
+// both again restores the original, unshared filenames.
+//
+// Sharding is unsupported in async mode, where SetAsyncMaxSize is the
+// equivalent: the flusher goroutine that owns the file handle never
+// consults l.shardBytes/l.shardLines, so this would otherwise be a
+// silent no-op once EnableAsync has been called. Calling it after
+// EnableAsync is itself a no-op.
+func (l *Logger) SetMaxSize(bytes int64) {
+	if l.async.Load() != nil {
+		return
+	}
+	l.maxSize.Store(bytes)
+	l.buildFormatString()
+}
+
+// SetMaxLines sets the line-count threshold at which a log file is
+// rotated to a new shard within the same layout bucket. A zero value
+// (the default) disables line-based rotation.
+//
+// As with SetMaxSize, this is unsupported in async mode and is a no-op
+// once EnableAsync has been called.
+func (l *Logger) SetMaxLines(n int64) {
+	if l.async.Load() != nil {
+		return
+	}
+	l.maxLines.Store(n)
+	l.buildFormatString()
+}
+
+// shardingEnabled reports whether either MaxSize or MaxLines has been
+// configured. maxSize/maxLines are atomic.Int64 specifically so this
+// can be called unlocked from hot paths (Write, FileName,
+// buildFormatString) without racing SetMaxSize/SetMaxLines.
+func (l *Logger) shardingEnabled() bool {
+	return l.maxSize.Load() > 0 || l.maxLines.Load() > 0
+}
+
+// writeSharded is Write's path for when MaxSize or MaxLines is
+// configured: it tracks bytes and lines written to the current shard
+// and rolls over to the next shard index, within the same layout
+// bucket, once a threshold is crossed. shardMu is held across the
+// threshold check, the write and the counter update so that concurrent
+// writers can't both pass the check before either one's bytes/lines are
+// accounted for.
+func (l *Logger) writeSharded(data []byte) (int, error) {
+	l.shardMu.Lock()
+	defer l.shardMu.Unlock()
+
+	bucket := l.timeFn().Format(l.layout)
+	l.advanceShardLocked(bucket, int64(len(data)))
+
+	spec := l.fmtSpec.Load()
+	name := l.namePool.Get().([]byte)[:0]
+	name = append(name, spec.pre...)
+	name = append(name, bucket...)
+	name = append(name, spec.mid...)
+	name = appendZeroPadded(name, l.shardIndex.Load(), 3)
+	name = append(name, spec.post...)
+
+	n, err := l.writeToName(name, data)
+
+	l.shardBytes.Add(int64(n))
+	l.shardLines.Add(int64(bytes.Count(data, []byte{'\n'})))
+
+	return n, err
+}
+
+// resumeShardForBucket is resumeIfNewBucketLocked for callers, such as
+// FileName, that need the current shard resumed/up to date for a bucket
+// they haven't seen yet without performing a write. Unlike
+// advanceShardLocked, it never advances the shard index for a bucket
+// that's already current, so calling it repeatedly with no intervening
+// Write (e.g. FileName called twice in a row, or from the debug-only
+// String()) can't push the shard index forward on its own.
+func (l *Logger) resumeShardForBucket(bucket string) {
+	l.shardMu.Lock()
+	defer l.shardMu.Unlock()
+	l.resumeIfNewBucketLocked(bucket)
+}
+
+// advanceShardLocked resumes the shard counters when bucket is a new
+// layout bucket (see resumeIfNewBucketLocked), or, for a bucket already
+// in progress, advances to the next shard index when writing incoming
+// more bytes, or one more line, would cross the configured threshold.
+// Callers must hold l.shardMu.
+func (l *Logger) advanceShardLocked(bucket string, incoming int64) {
+	if l.resumeIfNewBucketLocked(bucket) {
+		return
+	}
+
+	maxSize, maxLines := l.maxSize.Load(), l.maxLines.Load()
+
+	overSize := maxSize > 0 && l.shardBytes.Load()+incoming > maxSize
+	overLines := maxLines > 0 && l.shardLines.Load() >= maxLines
+	if overSize || overLines {
+		l.shardIndex.Add(1)
+		l.shardBytes.Store(0)
+		l.shardLines.Store(0)
+	}
+}
+
+// resumeIfNewBucketLocked resets the shard counters, resuming from
+// whatever shard index is already on disk for bucket, when bucket is a
+// layout bucket the logger hasn't seen yet, reporting whether it did
+// so. It never advances the shard index for a bucket already in
+// progress, so it's safe to call from read-only paths like FileName.
+// Callers must hold l.shardMu.
+func (l *Logger) resumeIfNewBucketLocked(bucket string) bool {
+	if prev := l.bucket.Load(); prev != nil && *prev == bucket {
+		return false
+	}
+
+	l.bucket.Store(&bucket)
+	idx, size := l.resumeShard(bucket)
+	l.shardIndex.Store(idx)
+	l.shardBytes.Store(size)
+	l.shardLines.Store(0)
+	return true
+}
+
+// resumeShard scans l.path for shard files already on disk for bucket
+// and returns the highest shard index found (0 if none) along with
+// that shard's current size in bytes, so a restart resumes appending
+// to it rather than clobbering it with a fresh shard 0. It does not
+// attempt to recover the exact line count of a resumed shard.
+func (l *Logger) resumeShard(bucket string) (index, size int64) {
+	spec := l.fmtSpec.Load()
+	pre, post := string(spec.pre), string(spec.post)
+	bucketPrefix := pre + bucket + "."
+
+	matches, err := filepath.Glob(filepath.Join(l.path, bucketPrefix+"*"+post))
+	if err != nil {
+		return 0, 0
+	}
+
+	index = -1
+	var best string
+	for _, m := range matches {
+		name := filepath.Base(m)
+		if !strings.HasPrefix(name, bucketPrefix) || !strings.HasSuffix(name, post) {
+			continue
+		}
+
+		digits := name[len(bucketPrefix) : len(name)-len(post)]
+		n, err := strconv.ParseInt(digits, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		if n > index {
+			index = n
+			best = m
+		}
+	}
+
+	if index < 0 {
+		return 0, 0
+	}
+
+	if fi, err := os.Stat(best); err == nil {
+		size = fi.Size()
+	}
+
+	return index, size
+}
+
+// appendZeroPadded appends the base-10 digits of n to buf, left-padded
+// with zeros to width, without going through fmt.Sprintf.
+func appendZeroPadded(buf []byte, n int64, width int) []byte {
+	start := len(buf)
+	buf = strconv.AppendInt(buf, n, 10)
+	digits := len(buf) - start
+
+	if digits >= width {
+		return buf
+	}
+
+	pad := width - digits
+	buf = append(buf, make([]byte, pad)...)
+	copy(buf[start+pad:], buf[start:start+digits])
+	for i := 0; i < pad; i++ {
+		buf[start+i] = '0'
+	}
+
+	return buf
+}
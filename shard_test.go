@@ -0,0 +1,76 @@
+package gyro
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxSizeShards(t *testing.T) {
+	dir := t.TempDir()
+
+	logger, err := New(dir)
+	require.NoError(t, err)
+
+	logger.SetPrefix("pre")
+	logger.SetTimeFunction(func() time.Time { return time.Unix(0, 0).UTC() })
+	logger.SetMaxSize(10)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, logger.WriteString("hello\n"))
+	}
+
+	assert.Equal(t, "pre1970-01-01T00.002.log", logger.FileName())
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+}
+
+func TestFileNameDoesNotAdvanceShard(t *testing.T) {
+	dir := t.TempDir()
+
+	logger, err := New(dir)
+	require.NoError(t, err)
+
+	logger.SetPrefix("pre")
+	logger.SetTimeFunction(func() time.Time { return time.Unix(0, 0).UTC() })
+	logger.SetMaxSize(10)
+
+	// A single write already over the threshold leaves the shard's
+	// counters over MaxSize until the next write; FileName must not
+	// treat that as a reason to advance on its own.
+	require.NoError(t, logger.WriteString("hello world, this line alone exceeds ten bytes\n"))
+
+	name := logger.FileName()
+	assert.Equal(t, name, logger.FileName())
+	assert.Equal(t, "pre1970-01-01T00.000.log", name)
+}
+
+func TestMaxSizeResumesHighestShardOnRestart(t *testing.T) {
+	dir := t.TempDir()
+	now := func() time.Time { return time.Unix(0, 0).UTC() }
+
+	first, err := New(dir)
+	require.NoError(t, err)
+	first.SetPrefix("pre")
+	first.SetTimeFunction(now)
+	first.SetMaxSize(1000)
+	require.NoError(t, first.WriteString("hello\n"))
+	require.NoError(t, first.Close())
+
+	second, err := New(dir)
+	require.NoError(t, err)
+	second.SetPrefix("pre")
+	second.SetTimeFunction(now)
+	second.SetMaxSize(1000)
+	require.NoError(t, second.WriteString("world\n"))
+	require.NoError(t, second.Close())
+
+	contents, err := os.ReadFile(dir + "/pre1970-01-01T00.000.log")
+	require.NoError(t, err)
+	assert.Equal(t, "hello\nworld\n", string(contents))
+}
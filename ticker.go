@@ -0,0 +1,65 @@
+package gyro
+
+import (
+	"strings"
+	"time"
+)
+
+// timeTicker fires at the next rotation boundary implied by a
+// time.Format layout, e.g. once an hour for "2006-01-02T15" or once a
+// day for "2006-01-02". Unlike time.Ticker it does not fire at a fixed
+// interval from creation time; it always lines up with the boundary the
+// layout would actually rotate on.
+type timeTicker struct {
+	layout string
+	now    func() time.Time
+	timer  *time.Timer
+	C      <-chan time.Time
+}
+
+// newTimeTicker creates a timeTicker armed for the next boundary
+// implied by layout, using now to read the current time.
+func newTimeTicker(layout string, now func() time.Time) *timeTicker {
+	t := &timeTicker{layout: layout, now: now}
+	timer := time.NewTimer(t.nextBoundary().Sub(now()))
+	t.timer = timer
+	t.C = timer.C
+	return t
+}
+
+// reset re-arms the ticker for the next boundary after the one that
+// just fired.
+func (t *timeTicker) reset() {
+	t.timer.Reset(t.nextBoundary().Sub(t.now()))
+}
+
+// Stop stops the ticker. It does not close t.C.
+func (t *timeTicker) Stop() {
+	t.timer.Stop()
+}
+
+func (t *timeTicker) nextBoundary() time.Time {
+	return nextLayoutBoundary(t.layout, t.now())
+}
+
+// nextLayoutBoundary returns the next instant after t at which the
+// finest-resolution reference-time element present in layout rolls
+// over, e.g. the top of the next hour for a layout containing "15".
+func nextLayoutBoundary(layout string, t time.Time) time.Time {
+	switch {
+	case strings.Contains(layout, "05"):
+		return t.Truncate(time.Second).Add(time.Second)
+	case strings.Contains(layout, "04"):
+		return t.Truncate(time.Minute).Add(time.Minute)
+	case strings.Contains(layout, "15") || strings.Contains(layout, "03"):
+		return t.Truncate(time.Hour).Add(time.Hour)
+	case strings.Contains(layout, "02"):
+		y, m, d := t.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location()).AddDate(0, 0, 1)
+	case strings.Contains(layout, "01") || strings.Contains(layout, "Jan"):
+		y, m, _ := t.Date()
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location()).AddDate(0, 1, 0)
+	default:
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location()).AddDate(1, 0, 0)
+	}
+}